@@ -1,7 +1,7 @@
 package fsext
 
 import (
-	"fmt"
+	"context"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -9,12 +9,8 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/bmatcuk/doublestar/v4"
-	"github.com/charlievieth/fastwalk"
-
-	ignore "github.com/sabhiram/go-gitignore"
 )
 
 var (
@@ -74,103 +70,157 @@ func SkipHidden(path string) bool {
 	return false
 }
 
-// FastGlobWalker provides gitignore-aware file walking with fastwalk
+// FastGlobWalker answers .gitignore/.crushignore skip checks for callers
+// that only need that. It wraps the same ignore tracking the Walker uses;
+// it does not walk anything itself.
 type FastGlobWalker struct {
-	gitignore *ignore.GitIgnore
-	rootPath  string
+	tracker *ignoreTracker
 }
 
 func NewFastGlobWalker(searchPath string) *FastGlobWalker {
-	walker := &FastGlobWalker{
-		rootPath: searchPath,
-	}
-
-	// Load gitignore if it exists
-	gitignorePath := filepath.Join(searchPath, ".gitignore")
-	if _, err := os.Stat(gitignorePath); err == nil {
-		if gi, err := ignore.CompileIgnoreFile(gitignorePath); err == nil {
-			walker.gitignore = gi
-		}
+	return &FastGlobWalker{
+		tracker: newIgnoreTracker(searchPath, []string{".gitignore", ".crushignore"}),
 	}
+}
 
-	return walker
+// ShouldSkip reports whether path is excluded by the walker's ignore files.
+func (w *FastGlobWalker) ShouldSkip(path string) bool {
+	return w.tracker.shouldSkip(path)
 }
 
-func shouldSkip(path, rootPath string, gitignore *ignore.GitIgnore) bool {
-	if SkipHidden(path) {
-		return true
-	}
+// globShardCount is the number of consumer goroutines GlobWithDoubleStar uses
+// to drain Walker.Walk's result channel, each appending to its own buffer so
+// matches don't funnel through a single mutex.
+const globShardCount = 16
 
-	if gitignore != nil {
-		relPath, err := filepath.Rel(rootPath, path)
-		if err == nil && gitignore.MatchesPath(relPath) {
-			return true
-		}
-	}
-
-	return false
+// WalkOptions lets callers prune the walk beyond the built-in gitignore
+// handling, e.g. so the file-indexer or an LSP workspace scanner can share a
+// single walk and still apply their own project-specific rules.
+type WalkOptions struct {
+	// SkipDir, if non-nil, is consulted for every directory below
+	// searchPath (searchPath itself is not passed to it). Returning true is
+	// equivalent to the walk function returning filepath.SkipDir.
+	SkipDir func(path string) bool
+	// SkipFiles, if non-nil, is consulted for every file and excludes it
+	// from the results without affecting the directories around it.
+	SkipFiles func(path string) bool
 }
 
+// GlobWithDoubleStar matches pattern against files under searchPath. It is a
+// convenience wrapper around GlobWithDoubleStarContext for callers that don't
+// need cancellation or custom pruning.
 func GlobWithDoubleStar(pattern, searchPath string, limit int) ([]string, bool, error) {
-	var mu sync.Mutex
-	walker := NewFastGlobWalker(searchPath)
-	var matches []FileInfo
-	conf := fastwalk.Config{
-		Follow: true,
-		// Use forward slashes when running a Windows binary under WSL or MSYS
-		ToSlash: fastwalk.DefaultToSlash(),
-		Sort:    fastwalk.SortFilesFirst,
-	}
-	rootPath, gitignore := walker.rootPath, walker.gitignore
-	err := fastwalk.Walk(&conf, searchPath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip files we can't access
+	return GlobWithDoubleStarContext(context.Background(), pattern, searchPath, limit, WalkOptions{})
+}
+
+// GlobWithDoubleStarContext is GlobWithDoubleStar with a cancellable context
+// and WalkOptions for callers that want to prune subtrees themselves. It is
+// built on top of the shared Walker rather than driving fastwalk itself.
+//
+// Directories are checked against the on-disk walk cache before being
+// descended into: when a directory's mtime and entry count match what was
+// recorded on a previous call for the same (searchPath, pattern), its cached
+// matches are reused and the walker skips the subtree entirely.
+func GlobWithDoubleStarContext(ctx context.Context, pattern, searchPath string, limit int, opts WalkOptions) ([]string, bool, error) {
+	walkCtx, stopWalk := context.WithCancel(ctx)
+	defer stopWalk()
+
+	cacheFile := loadWalkCacheFile(searchPath)
+	cacheEntry := cacheFile.Patterns[pattern]
+
+	w := NewWalker(searchPath)
+	w.Include = []string{pattern}
+
+	var cacheMu sync.Mutex
+	var cacheHitMatches []FileInfo
+	var freshStamps sync.Map // dir -> dirStamp
+	var hitDirs sync.Map     // dir -> struct{}
+
+	w.SkipDir = func(path string) bool {
+		if opts.SkipDir != nil && opts.SkipDir(path) {
+			return true
 		}
 
-		if d.IsDir() {
-			mu.Lock()
-			if shouldSkip(path, rootPath, gitignore) {
-				mu.Unlock()
-				return filepath.SkipDir
+		stamp, ok := statDir(path)
+		if !ok {
+			return false
+		}
+		if cached, hit := cacheEntry.unchanged(path, stamp); hit {
+			hitDirs.Store(path, struct{}{})
+			cacheMu.Lock()
+			for _, m := range cached.Matches {
+				// The directory stamp only proves no file was added or
+				// removed, not that a matched file's own contents (and
+				// thus its ModTime) are still what the cache recorded, so
+				// re-stat before trusting it for the recency sort below.
+				info, err := os.Stat(m.Path)
+				if err != nil {
+					continue
+				}
+				cacheHitMatches = append(cacheHitMatches, FileInfo{Path: m.Path, ModTime: info.ModTime()})
 			}
-			mu.Unlock()
-			return nil
+			cacheMu.Unlock()
+			return true
 		}
 
-		mu.Lock()
-		if shouldSkip(path, rootPath, gitignore) {
-			mu.Unlock()
-			return nil
-		}
-		mu.Unlock()
+		freshStamps.Store(path, stamp)
+		return false
+	}
+	w.SkipFiles = opts.SkipFiles
 
-		// Check if path matches the pattern
-		relPath, err := filepath.Rel(searchPath, path)
-		if err != nil {
-			relPath = path
-		}
+	entries := w.Walk(walkCtx)
 
-		matched, err := doublestar.Match(pattern, relPath)
-		if err != nil || !matched {
-			return nil
-		}
+	var shards [globShardCount]struct {
+		mu      sync.Mutex
+		matches []FileInfo
+	}
+	var matchCount atomic.Int64
+	var limitHit atomic.Bool
 
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
+	var wg sync.WaitGroup
+	wg.Add(globShardCount)
+	for i := range shards {
+		shard := &shards[i]
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				if entry.Err != nil {
+					continue
+				}
 
-		mu.Lock()
-		defer mu.Unlock()
+				info, err := entry.Info.Info()
+				if err != nil {
+					continue
+				}
 
-		matches = append(matches, FileInfo{Path: path, ModTime: info.ModTime()})
-		if limit > 0 && len(matches) >= limit*2 {
-			return filepath.SkipAll
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, false, fmt.Errorf("fastwalk error: %w", err)
+				shard.mu.Lock()
+				shard.matches = append(shard.matches, FileInfo{
+					Path:    filepath.Join(entry.ParentDir, entry.Info.Name()),
+					ModTime: info.ModTime(),
+				})
+				shard.mu.Unlock()
+
+				if limit > 0 && matchCount.Add(1) >= int64(limit*2) {
+					limitHit.Store(true)
+					stopWalk()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	var matches []FileInfo
+	matches = append(matches, cacheHitMatches...)
+	for i := range shards {
+		matches = append(matches, shards[i].matches...)
+	}
+
+	if !limitHit.Load() {
+		updateWalkCache(searchPath, pattern, cacheEntry, &freshStamps, &hitDirs, matches)
 	}
 
 	sort.Slice(matches, func(i, j int) bool {
@@ -0,0 +1,145 @@
+package fsext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// FuzzyOptions configures FuzzyFind.
+type FuzzyOptions struct {
+	// Query, if non-empty, filters the walk's results non-interactively: via
+	// `fzf --filter` when fzf is on $PATH, or an in-process fuzzy matcher
+	// otherwise.
+	Query string
+	// Interactive launches fzf bound to Stdout for a live picker instead of
+	// filtering by Query. It requires fzf to be on $PATH.
+	Interactive bool
+	// Stdout receives the interactive fzf UI. Defaults to os.Stdout.
+	Stdout io.Writer
+	// Limit caps the number of candidates collected from the walk before
+	// filtering. Zero means unlimited.
+	Limit int
+}
+
+// FuzzyFind walks searchPath the same way GlobWithDoubleStar does (honoring
+// .gitignore/.crushignore) and either fuzzy-filters the resulting paths
+// against opts.Query or, when opts.Interactive is set, hands them to an
+// interactive fzf session so the agent (or a TUI file picker) can ask for
+// "the file that looks like `httphandlr`" without an exact glob.
+func FuzzyFind(ctx context.Context, searchPath string, opts FuzzyOptions) ([]string, error) {
+	candidates, err := walkAllPaths(ctx, searchPath, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Interactive {
+		return fzfInteractive(candidates, opts.Stdout)
+	}
+	if opts.Query == "" {
+		return candidates, nil
+	}
+	if fzfPath != "" {
+		return fzfFilter(candidates, opts.Query)
+	}
+	return fuzzyMatchInProcess(candidates, opts.Query), nil
+}
+
+// walkAllPaths collects every file under searchPath, gitignore rules
+// applied, with no include/exclude pattern of its own: the filtering for
+// FuzzyFind happens afterwards, against the full candidate list.
+func walkAllPaths(ctx context.Context, searchPath string, limit int) ([]string, error) {
+	walkCtx, stopWalk := context.WithCancel(ctx)
+	defer stopWalk()
+
+	w := NewWalker(searchPath)
+
+	var paths []string
+	for entry := range w.Walk(walkCtx) {
+		if entry.Err != nil {
+			continue
+		}
+		// Entries already in flight when stopWalk runs are drained, not kept.
+		if limit > 0 && len(paths) >= limit {
+			continue
+		}
+		paths = append(paths, filepath.Join(entry.ParentDir, entry.Info.Name()))
+		if limit > 0 && len(paths) >= limit {
+			stopWalk()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// fzfFilter runs candidates through `fzf --filter query`, fzf's
+// non-interactive scoring mode.
+func fzfFilter(candidates []string, query string) ([]string, error) {
+	cmd := exec.Command(fzfPath, "--filter", query)
+	cmd.Stdin = strings.NewReader(strings.Join(candidates, "\n"))
+
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		// fzf exits 1 when the filter matches nothing; that's not failure.
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("fzf filter: %w", err)
+		}
+	}
+
+	return splitNonEmpty(out.String()), nil
+}
+
+// fzfInteractive runs a live fzf session over candidates. fzf reads the
+// candidate list from stdin and opens /dev/tty directly for keyboard
+// control, so stdout can be safely captured for the final selection while
+// stderr carries the UI through to the caller's terminal.
+func fzfInteractive(candidates []string, stdout io.Writer) ([]string, error) {
+	if fzfPath == "" {
+		return nil, fmt.Errorf("fzf not found in $PATH")
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	cmd := exec.Command(fzfPath)
+	cmd.Stdin = strings.NewReader(strings.Join(candidates, "\n"))
+	cmd.Stderr = stdout
+
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, nil // user cancelled the picker
+		}
+		return nil, fmt.Errorf("fzf: %w", err)
+	}
+
+	return splitNonEmpty(out.String()), nil
+}
+
+// fuzzyMatchInProcess is the fallback used when fzf isn't installed.
+func fuzzyMatchInProcess(candidates []string, query string) []string {
+	matches := fuzzy.Find(query, candidates)
+	results := make([]string, len(matches))
+	for i, m := range matches {
+		results[i] = m.Str
+	}
+	return results
+}
+
+func splitNonEmpty(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,175 @@
+package fsext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// ignoreTracker answers whether a path should be skipped, honoring every
+// .gitignore/.crushignore between the root and that path the way git does:
+// one matcher per directory, compiled from the root's rules down to that
+// directory's own, so a nested `!` negation can re-include what an outer
+// rule excluded. It also folds in a global $XDG_CONFIG_HOME/crush/ignore.
+type ignoreTracker struct {
+	rootPath         string
+	excludeFilenames []string
+	globalLines      []string
+
+	mu       sync.Mutex
+	lines    map[string][]string
+	matchers map[string]*ignore.GitIgnore
+}
+
+func newIgnoreTracker(rootPath string, excludeFilenames []string) *ignoreTracker {
+	t := &ignoreTracker{
+		rootPath:         rootPath,
+		excludeFilenames: excludeFilenames,
+		globalLines:      loadGlobalIgnoreLines(),
+		lines:            make(map[string][]string),
+		matchers:         make(map[string]*ignore.GitIgnore),
+	}
+
+	t.lines[rootPath] = append(append([]string{}, t.globalLines...), t.dirLines(rootPath)...)
+
+	return t
+}
+
+// loadGlobalIgnoreLines reads $XDG_CONFIG_HOME/crush/ignore, if present.
+func loadGlobalIgnoreLines() []string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+
+	data, err := os.ReadFile(filepath.Join(configHome, "crush", "ignore"))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// dirLines reads the exclude file(s) present directly in dir and rewrites
+// each line so it can be merged into a single root-anchored matcher.
+func (t *ignoreTracker) dirLines(dir string) []string {
+	var raw []string
+	for _, name := range t.excludeFilenames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		raw = append(raw, strings.Split(string(data), "\n")...)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+
+	relDir, err := filepath.Rel(t.rootPath, dir)
+	if err != nil {
+		relDir = "."
+	}
+	relDir = filepath.ToSlash(relDir)
+
+	lines := make([]string, len(raw))
+	for i, line := range raw {
+		lines[i] = rewriteIgnoreLine(relDir, line)
+	}
+	return lines
+}
+
+// rewriteIgnoreLine rewrites one raw line from the ignore file found in
+// relDir (relative to the tracker's root) so that compiling it alongside
+// every other directory's lines, root first, reproduces git's semantics:
+// later (more specific) rules are listed after earlier ones, so the
+// underlying matcher's own last-match-wins behavior decides precedence.
+// Comments and blank lines pass through unchanged.
+func rewriteIgnoreLine(relDir, line string) string {
+	if relDir == "." || relDir == "" {
+		return line
+	}
+
+	trimmed := strings.TrimRight(line, "\r")
+	content := strings.TrimLeft(trimmed, " \t")
+	if content == "" || strings.HasPrefix(content, "#") {
+		return trimmed
+	}
+
+	negate := strings.HasPrefix(content, "!")
+	pattern := strings.TrimPrefix(content, "!")
+
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	// A pattern with a "/" only at the end (or nowhere) matches at any depth
+	// under the ignore file's directory; anything else is already anchored
+	// to that directory per gitignore's own rules.
+	if anchored || strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		pattern = relDir + "/" + pattern
+	} else {
+		pattern = relDir + "/**/" + pattern
+	}
+
+	if negate {
+		pattern = "!" + pattern
+	}
+	return pattern
+}
+
+// matcherFor returns the single matcher that applies to dir, built from
+// every ancestor's ignore lines (root first) plus dir's own. It lazily
+// compiles and caches matchers as new directories are discovered.
+func (t *ignoreTracker) matcherFor(dir string) *ignore.GitIgnore {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if m, ok := t.matchers[dir]; ok {
+		return m
+	}
+
+	// Walk up to the nearest ancestor we've already resolved (the root
+	// directory is always seeded at construction time), then rebuild the
+	// line chain back down to dir, caching every directory along the way.
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, ok := t.lines[d]; ok {
+			break
+		}
+		missing = append(missing, d)
+		if d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		d := missing[i]
+		parent := t.lines[filepath.Dir(d)]
+		t.lines[d] = append(append([]string{}, parent...), t.dirLines(d)...)
+	}
+
+	m := ignore.CompileIgnoreLines(t.lines[dir]...)
+	t.matchers[dir] = m
+	return m
+}
+
+func (t *ignoreTracker) shouldSkip(path string) bool {
+	relPath, err := filepath.Rel(t.rootPath, path)
+	if err != nil {
+		return false
+	}
+
+	// SkipHidden must see only the path below rootPath: checking the
+	// absolute path would treat an ancestor directory name like a checkout
+	// under /tmp or .../build/... as if the walk root itself were hidden.
+	if SkipHidden(relPath) {
+		return true
+	}
+
+	return t.matcherFor(filepath.Dir(path)).MatchesPath(filepath.ToSlash(relPath))
+}
@@ -0,0 +1,66 @@
+package fsext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreTrackerShouldSkip(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.md\n")
+	mkdir(t, filepath.Join(root, "docs"))
+	writeFile(t, filepath.Join(root, "docs", ".gitignore"), "!README.md\n")
+
+	tracker := newIgnoreTracker(root, []string{".gitignore"})
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"root file matched by root .gitignore", filepath.Join(root, "a.md"), true},
+		{"nested file re-included by a negation", filepath.Join(root, "docs", "README.md"), false},
+		{"nested file still matched by root .gitignore", filepath.Join(root, "docs", "other.md"), true},
+		{"non-matching file", filepath.Join(root, "main.go"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracker.shouldSkip(tt.path); got != tt.want {
+				t.Errorf("shouldSkip(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIgnoreTrackerShouldSkipIgnoresAncestorSegments guards against
+// treating a path component above rootPath (e.g. a checkout under /tmp, or
+// nested in a build/ directory) as if it were part of the walk itself.
+func TestIgnoreTrackerShouldSkipIgnoresAncestorSegments(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "build", "project")
+	mkdir(t, root)
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+
+	tracker := newIgnoreTracker(root, []string{".gitignore"})
+
+	if tracker.shouldSkip(filepath.Join(root, "main.go")) {
+		t.Errorf("shouldSkip reported true for a file under an ancestor named like a common ignored dir")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writeFile(%q): %v", path, err)
+	}
+}
+
+func mkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir(%q): %v", path, err)
+	}
+}
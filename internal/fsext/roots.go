@@ -0,0 +1,175 @@
+package fsext
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// RootType classifies a directory discovered by DiscoverRoots.
+type RootType int
+
+const (
+	// RootCurrentModule is the module (or workspace) containing startDir,
+	// rooted at the nearest ancestor with a go.mod or go.work file.
+	RootCurrentModule RootType = iota
+	// RootModuleCache is the module directory inside GOMODCACHE that holds
+	// a dependency's source, e.g. .../pkg/mod/github.com/foo/bar@v1.2.3.
+	RootModuleCache
+	// RootGOPATH is a GOPATH entry startDir lives under.
+	RootGOPATH
+	// RootGOROOT is the Go standard library source tree.
+	RootGOROOT
+	// RootGenericRepo is a non-Go project root, detected by a marker file
+	// such as .git, package.json, Cargo.toml, or pyproject.toml.
+	RootGenericRepo
+)
+
+func (t RootType) String() string {
+	switch t {
+	case RootCurrentModule:
+		return "current-module"
+	case RootModuleCache:
+		return "module-cache"
+	case RootGOPATH:
+		return "gopath"
+	case RootGOROOT:
+		return "goroot"
+	case RootGenericRepo:
+		return "generic-repo"
+	default:
+		return "unknown"
+	}
+}
+
+// Root is a project root discovered by DiscoverRoots.
+type Root struct {
+	Path string
+	Type RootType
+}
+
+// genericRepoMarkers are files whose presence in a directory marks it as the
+// root of a non-Go (or not-only-Go) project.
+var genericRepoMarkers = []string{".git", "package.json", "Cargo.toml", "pyproject.toml"}
+
+// DiscoverRoots classifies the project roots around startDir: the GOMODCACHE
+// module, any GOPATH entries and GOROOT that contain it, then, walking up,
+// the nearest go.mod/go.work directory and the first directory with a
+// generic repo marker. The walk stops at that marker directory, so a module
+// above it is not reported, and the directory taken as the current module is
+// not itself checked for a generic marker.
+func DiscoverRoots(startDir string) ([]Root, error) {
+	absStart, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []Root
+	seen := make(map[string]bool)
+	add := func(path string, typ RootType) {
+		path = filepath.Clean(path)
+		key := path + "\x00" + typ.String()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		roots = append(roots, Root{Path: path, Type: typ})
+	}
+
+	if modCacheRoot := moduleCacheRoot(absStart); modCacheRoot != "" {
+		add(modCacheRoot, RootModuleCache)
+	}
+	for _, gp := range gopaths() {
+		if isWithin(absStart, gp) {
+			add(gp, RootGOPATH)
+		}
+	}
+	if goroot := runtime.GOROOT(); goroot != "" && isWithin(absStart, filepath.Join(goroot, "src")) {
+		add(filepath.Join(goroot, "src"), RootGOROOT)
+	}
+
+	moduleFound := false
+	for dir := absStart; ; {
+		if !moduleFound && (fileExists(filepath.Join(dir, "go.work")) || fileExists(filepath.Join(dir, "go.mod"))) {
+			add(dir, RootCurrentModule)
+			moduleFound = true
+		} else if hasGenericMarker(dir) {
+			add(dir, RootGenericRepo)
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return roots, nil
+}
+
+func hasGenericMarker(dir string) bool {
+	for _, marker := range genericRepoMarkers {
+		if fileExists(filepath.Join(dir, marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (!strings.HasPrefix(rel, "..") && rel != "")
+}
+
+func gopaths() []string {
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.SplitList(gopath)
+}
+
+// moduleCacheRoot returns the module's root directory inside GOMODCACHE
+// (e.g. .../pkg/mod/github.com/foo/bar@v1.2.3) if path is inside the module
+// cache, GOMODCACHE itself if path is inside it but under no "@" segment,
+// or "" otherwise.
+func moduleCacheRoot(path string) string {
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		gps := gopaths()
+		if len(gps) == 0 {
+			return ""
+		}
+		modCache = filepath.Join(gps[0], "pkg", "mod")
+	}
+
+	rel, err := filepath.Rel(modCache, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	// A module cache entry looks like <host>/<path...>/<module>@<version>,
+	// so the root is everything up to and including the first "@version"
+	// path segment.
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		if strings.Contains(part, "@") {
+			return filepath.Join(modCache, filepath.Join(parts[:i+1]...))
+		}
+	}
+	return modCache
+}
@@ -0,0 +1,231 @@
+package fsext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WalkCacheTTL bounds how long a cached walk entry is trusted before a fresh
+// walk is forced regardless of whether directory stamps still match. It is a
+// package variable rather than a parameter so long-lived callers (daemons,
+// the LSP server) can tune it without threading it through every call site.
+var WalkCacheTTL = 24 * time.Hour
+
+// walkCacheMatch is a single glob match as stored on disk, with the ModTime
+// it had when it was walked. On a cache hit GlobWithDoubleStarContext
+// re-stats each cached Path and sorts by that fresh ModTime instead.
+type walkCacheMatch struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// dirStamp records a directory's mtime and entry count at the time its
+// subtree was last walked, plus every match found anywhere under it. As
+// long as both fields still match on the next walk, the subtree is assumed
+// unchanged and Matches is reused instead of descending into it again.
+type dirStamp struct {
+	ModTime    time.Time        `json:"mod_time"`
+	EntryCount int              `json:"entry_count"`
+	Matches    []walkCacheMatch `json:"matches"`
+}
+
+// walkCacheEntry is the cached state for a single (root, pattern) pair.
+type walkCacheEntry struct {
+	SavedAt time.Time           `json:"saved_at"`
+	Dirs    map[string]dirStamp `json:"dirs"`
+}
+
+// walkCacheFile is the on-disk representation for one root: every pattern
+// globbed against it gets its own entry in the same file.
+type walkCacheFile struct {
+	Patterns map[string]walkCacheEntry `json:"patterns"`
+}
+
+var (
+	walkCacheLocksMu sync.Mutex
+	walkCacheLocks   = map[string]*sync.Mutex{}
+)
+
+// walkCacheLock returns the mutex guarding path's load-mutate-save sequence,
+// creating one on first reference. Without it, two concurrent globs against
+// the same root but different patterns can each load the file, mutate only
+// their own pattern, and save the whole thing back, silently losing
+// whichever update lands first.
+func walkCacheLock(path string) *sync.Mutex {
+	walkCacheLocksMu.Lock()
+	defer walkCacheLocksMu.Unlock()
+	mu, ok := walkCacheLocks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		walkCacheLocks[path] = mu
+	}
+	return mu
+}
+
+func walkCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "crush", "walkcache")
+}
+
+// walkCachePath returns the flat cache file for root, named after its
+// content-addressed (sha256) path so arbitrary roots don't need escaping.
+func walkCachePath(root string) string {
+	dir := walkCacheDir()
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(root)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func loadWalkCacheFile(root string) walkCacheFile {
+	empty := walkCacheFile{Patterns: map[string]walkCacheEntry{}}
+
+	path := walkCachePath(root)
+	if path == "" {
+		return empty
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty
+	}
+
+	var cache walkCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Patterns == nil {
+		return empty
+	}
+	return cache
+}
+
+func saveWalkCacheFile(root string, cache walkCacheFile) error {
+	path := walkCachePath(root)
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// InvalidateWalkCache removes the on-disk walk cache for root. The
+// filesystem watcher calls this when it sees changes under root so the next
+// glob re-walks it instead of trusting stale directory stamps. It is safe
+// to call even when no cache exists yet.
+func InvalidateWalkCache(root string) error {
+	path := walkCachePath(root)
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// statDir stamps dir with its current mtime and entry count.
+func statDir(dir string) (dirStamp, bool) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return dirStamp{}, false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return dirStamp{}, false
+	}
+	return dirStamp{ModTime: info.ModTime(), EntryCount: len(entries)}, true
+}
+
+// unchanged reports whether stamp still matches what was recorded for dir in
+// entry, and entry itself hasn't aged out under WalkCacheTTL.
+func (e walkCacheEntry) unchanged(dir string, stamp dirStamp) (dirStamp, bool) {
+	if time.Since(e.SavedAt) >= WalkCacheTTL {
+		return dirStamp{}, false
+	}
+	cached, ok := e.Dirs[dir]
+	if !ok {
+		return dirStamp{}, false
+	}
+	if !cached.ModTime.Equal(stamp.ModTime) || cached.EntryCount != stamp.EntryCount {
+		return dirStamp{}, false
+	}
+	return cached, true
+}
+
+// isUnderDir reports whether path is dir itself or nested under it.
+func isUnderDir(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// updateWalkCache persists the result of a completed (non-truncated) walk:
+// every freshly-stamped directory gets the matches found under it, and every
+// directory reused from cache this round is carried forward unchanged. Any
+// directory from the previous entry that was neither is dropped as stale.
+// The load-mutate-save sequence runs under the cache file's lock and reloads
+// the file right before merging, so a concurrent update to a different
+// pattern on the same root isn't clobbered by this call's now-stale read
+// from before the walk started. Saving is best-effort; a failure is logged
+// and otherwise ignored, since the cache is purely an optimization.
+func updateWalkCache(root, pattern string, oldEntry walkCacheEntry, freshStamps, hitDirs *sync.Map, matches []FileInfo) {
+	newEntry := walkCacheEntry{
+		SavedAt: time.Now(),
+		Dirs:    make(map[string]dirStamp),
+	}
+
+	freshStamps.Range(func(key, value any) bool {
+		dir := key.(string)
+		stamp := value.(dirStamp)
+
+		for _, m := range matches {
+			if isUnderDir(m.Path, dir) {
+				stamp.Matches = append(stamp.Matches, walkCacheMatch{Path: m.Path, ModTime: m.ModTime})
+			}
+		}
+		newEntry.Dirs[dir] = stamp
+		return true
+	})
+
+	hitDirs.Range(func(key, _ any) bool {
+		dir := key.(string)
+		if cached, ok := oldEntry.Dirs[dir]; ok {
+			newEntry.Dirs[dir] = cached
+		}
+		return true
+	})
+
+	path := walkCachePath(root)
+	if path == "" {
+		return
+	}
+	mu := walkCacheLock(path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	cacheFile := loadWalkCacheFile(root)
+	cacheFile.Patterns[pattern] = newEntry
+	if err := saveWalkCacheFile(root, cacheFile); err != nil {
+		slog.Warn("failed to save walk cache", "root", root, "error", err)
+	}
+}
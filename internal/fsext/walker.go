@@ -0,0 +1,164 @@
+package fsext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charlievieth/fastwalk"
+)
+
+// FastWalkInfo is one entry produced by Walker.Walk. ParentDir rides along
+// with Info since the walk is asynchronous relative to the consumer.
+type FastWalkInfo struct {
+	ParentDir string
+	Info      os.DirEntry
+	Err       error
+}
+
+// Walker is the shared file-discovery primitive behind glob, grep, and any
+// other walk in Crush. It supports include/exclude glob filtering, a chain
+// of per-directory exclude files (.gitignore, .crushignore, ...),
+// symlink-loop detection, and a bounded concurrency limit.
+type Walker struct {
+	// Root is the directory the walk starts from.
+	Root string
+	// Include, if non-empty, restricts results to files matching at least
+	// one of these doublestar patterns (relative to Root).
+	Include []string
+	// Exclude drops files and directories matching any of these doublestar
+	// patterns (relative to Root), in addition to ExcludeFilenames.
+	Exclude []string
+	// ExcludeFilenames are per-directory files auto-loaded and compiled into
+	// gitignore-style matchers as the walk descends, e.g. ".gitignore",
+	// ".crushignore", ".goimportsignore".
+	ExcludeFilenames []string
+	// FollowSymlinks controls whether the walk follows symlinked
+	// directories. Loops are always detected and broken regardless.
+	FollowSymlinks bool
+	// Concurrency caps the number of goroutines fastwalk uses to read
+	// directories. Zero means fastwalk's own default.
+	Concurrency int
+
+	// SkipDir and SkipFiles let callers prune the walk with logic that
+	// doesn't fit a glob, e.g. the file-indexer or an LSP workspace scanner
+	// sharing this walker but applying their own project-specific rules.
+	SkipDir   func(path string) bool
+	SkipFiles func(path string) bool
+}
+
+// NewWalker returns a Walker rooted at root with Crush's default ignore
+// files and symlink following enabled.
+func NewWalker(root string) *Walker {
+	return &Walker{
+		Root:             root,
+		ExcludeFilenames: []string{".gitignore", ".crushignore"},
+		FollowSymlinks:   true,
+	}
+}
+
+// Walk starts the walk in a background goroutine and streams results on the
+// returned channel, which is closed when the walk finishes or ctx is
+// cancelled.
+func (w *Walker) Walk(ctx context.Context) <-chan FastWalkInfo {
+	out := make(chan FastWalkInfo)
+
+	tracker := newIgnoreTracker(w.Root, w.ExcludeFilenames)
+	var visited sync.Map // real path -> struct{}, for symlink-loop detection
+
+	conf := fastwalk.Config{
+		Follow:     w.FollowSymlinks,
+		ToSlash:    fastwalk.DefaultToSlash(),
+		Sort:       fastwalk.SortFilesFirst,
+		NumWorkers: w.Concurrency,
+	}
+
+	go func() {
+		defer close(out)
+
+		_ = fastwalk.Walk(&conf, w.Root, func(path string, d os.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return filepath.SkipAll
+			}
+
+			if err != nil {
+				select {
+				case out <- FastWalkInfo{ParentDir: filepath.Dir(path), Info: d, Err: err}:
+				case <-ctx.Done():
+				}
+				return nil
+			}
+
+			if d.Type()&os.ModeSymlink != 0 && w.FollowSymlinks {
+				if real, err := filepath.EvalSymlinks(path); err == nil {
+					if _, loop := visited.LoadOrStore(real, struct{}{}); loop {
+						return nil
+					}
+				}
+			}
+
+			if d.IsDir() {
+				if path != w.Root && w.dirExcluded(path, tracker) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if w.fileExcluded(path, tracker) {
+				return nil
+			}
+
+			select {
+			case out <- FastWalkInfo{ParentDir: filepath.Dir(path), Info: d}:
+			case <-ctx.Done():
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}()
+
+	return out
+}
+
+func (w *Walker) dirExcluded(path string, tracker *ignoreTracker) bool {
+	if w.SkipDir != nil && w.SkipDir(path) {
+		return true
+	}
+	if tracker.shouldSkip(path) {
+		return true
+	}
+	return matchesAny(w.Exclude, w.Root, path)
+}
+
+func (w *Walker) fileExcluded(path string, tracker *ignoreTracker) bool {
+	if w.SkipFiles != nil && w.SkipFiles(path) {
+		return true
+	}
+	if tracker.shouldSkip(path) {
+		return true
+	}
+	if matchesAny(w.Exclude, w.Root, path) {
+		return true
+	}
+	if len(w.Include) > 0 && !matchesAny(w.Include, w.Root, path) {
+		return true
+	}
+	return false
+}
+
+// matchesAny reports whether path (relative to root) matches any of the
+// given doublestar patterns.
+func matchesAny(patterns []string, root, path string) bool {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		relPath = path
+	}
+	for _, pattern := range patterns {
+		if matched, err := doublestar.Match(pattern, relPath); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}